@@ -5,6 +5,9 @@ import (
 	"github.com/jackc/pgtype"
 )
 
+// convertDriverValuers unwraps SensitiveData before encoding so a wrapped value still reaches the wire protocol.
+// This is the only place a SensitiveData value is allowed to surface — see SensitiveData for the guarantee that it
+// never reaches logs, traces, or error messages.
 func convertDriverValuers(args []interface{}) ([]interface{}, error) {
 	for i, arg := range args {
 		if sens, ok := arg.(SensitiveData); ok {
@@ -0,0 +1,382 @@
+package pgxpool
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	errors "golang.org/x/xerrors"
+)
+
+// ReplicaLoadBalancePolicy selects which replica in a Cluster serves the next read.
+type ReplicaLoadBalancePolicy int
+
+const (
+	// RoundRobin cycles through healthy replicas in order. This is the default policy.
+	RoundRobin ReplicaLoadBalancePolicy = iota
+
+	// Random selects a healthy replica uniformly at random.
+	Random
+
+	// LeastAcquired selects the healthy replica with the fewest currently acquired connections, per Stat().
+	LeastAcquired
+
+	// Weighted selects a healthy replica at random, weighted by the Weight given in its ClusterReplicaConfig.
+	Weighted
+)
+
+// ClusterReplicaConfig describes one replica pool managed by a Cluster.
+type ClusterReplicaConfig struct {
+	// Config connects to the replica. Like any other pgxpool.Config it must have been created by ParseConfig.
+	Config *Config
+
+	// Weight is only consulted when the Cluster's LoadBalancePolicy is Weighted. Replicas with a larger Weight
+	// receive proportionally more reads. Defaults to 1 if less than 1.
+	Weight int
+}
+
+// ClusterConfig configures a Cluster.
+type ClusterConfig struct {
+	// Primary is used for writes and for any operation the router cannot safely send to a replica.
+	Primary *Config
+
+	// Replicas are used for read-only operations.
+	Replicas []ClusterReplicaConfig
+
+	// LoadBalancePolicy selects which healthy replica serves the next read. The default is RoundRobin.
+	LoadBalancePolicy ReplicaLoadBalancePolicy
+
+	// MaxReplicaFailures is the number of consecutive failures a replica must accumulate before it is demoted out
+	// of rotation for ReplicaCooldown. The default is 3.
+	MaxReplicaFailures int32
+
+	// ReplicaCooldown is how long a demoted replica is skipped before it is eligible again. The default is 30
+	// seconds.
+	ReplicaCooldown time.Duration
+
+	// PrimaryCheckPeriod is how often each replica is probed with "select pg_is_in_recovery()" to detect that it
+	// has been promoted, so failover is picked up automatically. The default is 15 seconds. A negative value
+	// disables the probe.
+	PrimaryCheckPeriod time.Duration
+}
+
+// clusterReplica tracks one replica pool along with the health bookkeeping the router uses to decide whether it is
+// eligible to serve a read.
+type clusterReplica struct {
+	pool   *Pool
+	weight int
+
+	mu              sync.Mutex
+	consecutiveFail int32
+	demotedUntil    time.Time
+	promoted        bool // true once detected to no longer be in recovery; permanently excluded from reads.
+}
+
+func (r *clusterReplica) healthy(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !r.promoted && now.After(r.demotedUntil)
+}
+
+func (r *clusterReplica) recordResult(err error, maxFailures int32, cooldown time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		r.consecutiveFail = 0
+		r.demotedUntil = time.Time{}
+		return
+	}
+
+	r.consecutiveFail++
+	if r.consecutiveFail >= maxFailures {
+		r.demotedUntil = time.Now().Add(cooldown)
+	}
+}
+
+func (r *clusterReplica) markPromoted() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.promoted = true
+}
+
+// Cluster manages a primary pool and a set of read replica pools behind a single *Pool-shaped surface, so it can be
+// used as a drop-in replacement wherever a *Pool is used. Query and QueryRow calls, and any transaction begun with
+// pgx.TxOptions{AccessMode: pgx.ReadOnly}, are routed to a replica chosen by LoadBalancePolicy; Exec, CopyFrom,
+// SendBatch, and any other transaction go to the primary. A replica that errors repeatedly is taken out of
+// rotation for ReplicaCooldown. A replica that reports it is no longer in recovery (i.e. it has been promoted) is
+// swapped in as the Cluster's new primary — see promote — and excluded from read rotation, since it is now the
+// primary rather than a replica.
+type Cluster struct {
+	primaryMu        sync.RWMutex
+	primary          *Pool
+	retiredPrimaries []*Pool // former primaries, superseded by a promotion swap; see promote.
+
+	replicas []*clusterReplica
+
+	policy             ReplicaLoadBalancePolicy
+	maxReplicaFailures int32
+	replicaCooldown    time.Duration
+
+	rrCounter uint32
+	closeChan chan struct{}
+}
+
+// getPrimary returns the pool currently treated as primary, accounting for any promotion swap made by promote.
+func (c *Cluster) getPrimary() *Pool {
+	c.primaryMu.RLock()
+	defer c.primaryMu.RUnlock()
+	return c.primary
+}
+
+// promote makes replica's pool the new primary. The superseded pool is left open rather than closed, since
+// in-flight work started before the swap may still be using it; it is retained on retiredPrimaries so Close still
+// shuts it down, but it is otherwise the operator's responsibility to decommission or reconfigure it once drained.
+func (c *Cluster) promote(replica *clusterReplica) {
+	c.primaryMu.Lock()
+	c.retiredPrimaries = append(c.retiredPrimaries, c.primary)
+	c.primary = replica.pool
+	c.primaryMu.Unlock()
+
+	replica.markPromoted()
+}
+
+// ConnectCluster creates a Cluster, connecting to the primary and all replicas. ctx can be used to cancel these
+// initial connections.
+func ConnectCluster(ctx context.Context, config ClusterConfig) (*Cluster, error) {
+	if config.Primary == nil {
+		return nil, errors.New("ClusterConfig.Primary must be set")
+	}
+
+	maxReplicaFailures := config.MaxReplicaFailures
+	if maxReplicaFailures == 0 {
+		maxReplicaFailures = 3
+	}
+
+	replicaCooldown := config.ReplicaCooldown
+	if replicaCooldown == 0 {
+		replicaCooldown = 30 * time.Second
+	}
+
+	primaryCheckPeriod := config.PrimaryCheckPeriod
+	if primaryCheckPeriod == 0 {
+		primaryCheckPeriod = 15 * time.Second
+	}
+
+	primary, err := ConnectConfig(ctx, config.Primary)
+	if err != nil {
+		return nil, errors.Errorf("connect primary: %w", err)
+	}
+
+	c := &Cluster{
+		primary:            primary,
+		policy:             config.LoadBalancePolicy,
+		maxReplicaFailures: maxReplicaFailures,
+		replicaCooldown:    replicaCooldown,
+		closeChan:          make(chan struct{}),
+	}
+
+	for _, rc := range config.Replicas {
+		weight := rc.Weight
+		if weight < 1 {
+			weight = 1
+		}
+
+		pool, err := ConnectConfig(ctx, rc.Config)
+		if err != nil {
+			c.Close()
+			return nil, errors.Errorf("connect replica: %w", err)
+		}
+
+		replica := &clusterReplica{pool: pool, weight: weight}
+		c.replicas = append(c.replicas, replica)
+
+		if primaryCheckPeriod > 0 {
+			go c.watchForPromotion(replica, primaryCheckPeriod)
+		}
+	}
+
+	return c, nil
+}
+
+// watchForPromotion periodically probes replica with "select pg_is_in_recovery()". Once it reports that it is no
+// longer a standby, it has been promoted: it becomes the Cluster's new primary, via promote, and this goroutine
+// exits since the replica's role is now permanently settled.
+func (c *Cluster) watchForPromotion(replica *clusterReplica, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.closeChan:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), period)
+			var inRecovery bool
+			err := replica.pool.QueryRow(ctx, "select pg_is_in_recovery()").Scan(&inRecovery)
+			cancel()
+
+			if err != nil {
+				replica.recordResult(err, c.maxReplicaFailures, c.replicaCooldown)
+				continue
+			}
+
+			if !inRecovery {
+				c.promote(replica)
+				return
+			}
+		}
+	}
+}
+
+// pickReplica returns a healthy replica chosen according to LoadBalancePolicy, or nil if none are currently
+// eligible, in which case callers should fall back to the primary.
+func (c *Cluster) pickReplica() *clusterReplica {
+	if len(c.replicas) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	healthy := make([]*clusterReplica, 0, len(c.replicas))
+	for _, r := range c.replicas {
+		if r.healthy(now) {
+			healthy = append(healthy, r)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	switch c.policy {
+	case Random:
+		return healthy[rand.Intn(len(healthy))]
+
+	case LeastAcquired:
+		best := healthy[0]
+		for _, r := range healthy[1:] {
+			if r.pool.Stat().AcquiredConns() < best.pool.Stat().AcquiredConns() {
+				best = r
+			}
+		}
+		return best
+
+	case Weighted:
+		total := 0
+		for _, r := range healthy {
+			total += r.weight
+		}
+		n := rand.Intn(total)
+		for _, r := range healthy {
+			if n < r.weight {
+				return r
+			}
+			n -= r.weight
+		}
+		return healthy[len(healthy)-1]
+
+	default: // RoundRobin
+		i := atomic.AddUint32(&c.rrCounter, 1)
+		return healthy[int(i)%len(healthy)]
+	}
+}
+
+// Close closes the current primary, any retired former primaries (see promote), and all replica pools.
+func (c *Cluster) Close() {
+	select {
+	case <-c.closeChan:
+	default:
+		close(c.closeChan)
+	}
+
+	c.primaryMu.RLock()
+	defer c.primaryMu.RUnlock()
+
+	// A promoted replica's pool is both a replica and the (current or retired) primary; close each pool only once,
+	// since Pool.Close may not be called twice.
+	closed := make(map[*Pool]bool)
+	closeOnce := func(p *Pool) {
+		if !closed[p] {
+			closed[p] = true
+			p.Close()
+		}
+	}
+
+	closeOnce(c.primary)
+	for _, p := range c.retiredPrimaries {
+		closeOnce(p)
+	}
+	for _, r := range c.replicas {
+		closeOnce(r.pool)
+	}
+}
+
+// Exec always runs against the primary.
+func (c *Cluster) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	return c.getPrimary().Exec(ctx, sql, arguments...)
+}
+
+// Query is routed to a replica when one is healthy, falling back to the primary otherwise or if the replica
+// returns an error.
+func (c *Cluster) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	if replica := c.pickReplica(); replica != nil {
+		rows, err := replica.pool.Query(ctx, sql, args...)
+		replica.recordResult(err, c.maxReplicaFailures, c.replicaCooldown)
+		if err == nil {
+			return rows, nil
+		}
+	}
+
+	return c.getPrimary().Query(ctx, sql, args...)
+}
+
+// QueryRow is routed to a replica when one is healthy, falling back to the primary otherwise or if the replica
+// cannot be acquired. The query itself is not issued until the returned pgx.Row is scanned, so an error from the
+// query itself still can't count against the replica's health here the way it does for Query and BeginTx — but a
+// replica that is down entirely fails at Acquire, before a row is even returned, so that failure is recorded.
+func (c *Cluster) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	if replica := c.pickReplica(); replica != nil {
+		conn, err := replica.pool.Acquire(ctx)
+		replica.recordResult(err, c.maxReplicaFailures, c.replicaCooldown)
+		if err == nil {
+			return conn.getPoolRow(conn.QueryRow(ctx, sql, args...))
+		}
+	}
+
+	return c.getPrimary().QueryRow(ctx, sql, args...)
+}
+
+// SendBatch always runs against the primary.
+func (c *Cluster) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return c.getPrimary().SendBatch(ctx, b)
+}
+
+// CopyFrom always runs against the primary.
+func (c *Cluster) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return c.getPrimary().CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+// Begin starts a read-write transaction on the primary.
+func (c *Cluster) Begin(ctx context.Context) (pgx.Tx, error) {
+	return c.getPrimary().Begin(ctx)
+}
+
+// BeginTx starts a transaction. A transaction with AccessMode pgx.ReadOnly is routed to a replica when one is
+// healthy; any other transaction, and any ReadOnly transaction when no replica is healthy, runs on the primary.
+func (c *Cluster) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	if txOptions.AccessMode == pgx.ReadOnly {
+		if replica := c.pickReplica(); replica != nil {
+			tx, err := replica.pool.BeginTx(ctx, txOptions)
+			replica.recordResult(err, c.maxReplicaFailures, c.replicaCooldown)
+			if err == nil {
+				return tx, nil
+			}
+		}
+	}
+
+	return c.getPrimary().BeginTx(ctx, txOptions)
+}
@@ -0,0 +1,145 @@
+package pgxpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/puddle"
+)
+
+func newTestPool(t *testing.T, acquireCount int) *Pool {
+	t.Helper()
+
+	p := puddle.NewPool(
+		func(ctx context.Context) (interface{}, error) { return struct{}{}, nil },
+		func(interface{}) {},
+		100,
+	)
+
+	resources := make([]*puddle.Resource, 0, acquireCount)
+	for i := 0; i < acquireCount; i++ {
+		res, err := p.Acquire(context.Background())
+		if err != nil {
+			t.Fatalf("acquiring test resource: %v", err)
+		}
+		resources = append(resources, res)
+	}
+	_ = resources // left acquired so Stat().AcquiredConns() reflects acquireCount
+
+	return &Pool{p: p}
+}
+
+func TestClusterPickReplicaPolicies(t *testing.T) {
+	t.Run("RoundRobin cycles through healthy replicas", func(t *testing.T) {
+		a := &clusterReplica{pool: newTestPool(t, 0)}
+		b := &clusterReplica{pool: newTestPool(t, 0)}
+		c := &Cluster{policy: RoundRobin, replicas: []*clusterReplica{a, b}}
+
+		seen := map[*clusterReplica]int{}
+		for i := 0; i < 4; i++ {
+			seen[c.pickReplica()]++
+		}
+		if seen[a] == 0 || seen[b] == 0 {
+			t.Errorf("RoundRobin did not visit both replicas: %v", seen)
+		}
+	})
+
+	t.Run("Random only returns a healthy replica", func(t *testing.T) {
+		healthy := &clusterReplica{pool: newTestPool(t, 0)}
+		demoted := &clusterReplica{pool: newTestPool(t, 0), demotedUntil: time.Now().Add(time.Hour)}
+		c := &Cluster{policy: Random, replicas: []*clusterReplica{healthy, demoted}}
+
+		for i := 0; i < 10; i++ {
+			if got := c.pickReplica(); got != healthy {
+				t.Fatalf("pickReplica() = %p, want the only healthy replica %p", got, healthy)
+			}
+		}
+	})
+
+	t.Run("LeastAcquired picks the replica with fewer acquired conns", func(t *testing.T) {
+		busy := &clusterReplica{pool: newTestPool(t, 5)}
+		idle := &clusterReplica{pool: newTestPool(t, 0)}
+		c := &Cluster{policy: LeastAcquired, replicas: []*clusterReplica{busy, idle}}
+
+		if got := c.pickReplica(); got != idle {
+			t.Errorf("pickReplica() = %p, want the idler replica %p", got, idle)
+		}
+	})
+
+	t.Run("Weighted favors the replica with the larger weight", func(t *testing.T) {
+		light := &clusterReplica{pool: newTestPool(t, 0), weight: 1}
+		heavy := &clusterReplica{pool: newTestPool(t, 0), weight: 99}
+		c := &Cluster{policy: Weighted, replicas: []*clusterReplica{light, heavy}}
+
+		counts := map[*clusterReplica]int{}
+		for i := 0; i < 100; i++ {
+			counts[c.pickReplica()]++
+		}
+		if counts[heavy] <= counts[light] {
+			t.Errorf("Weighted did not favor the heavier replica: %v", counts)
+		}
+	})
+
+	t.Run("no replicas returns nil", func(t *testing.T) {
+		c := &Cluster{}
+		if got := c.pickReplica(); got != nil {
+			t.Errorf("pickReplica() = %v, want nil", got)
+		}
+	})
+
+	t.Run("all replicas demoted returns nil", func(t *testing.T) {
+		r := &clusterReplica{pool: newTestPool(t, 0), demotedUntil: time.Now().Add(time.Hour)}
+		c := &Cluster{replicas: []*clusterReplica{r}}
+		if got := c.pickReplica(); got != nil {
+			t.Errorf("pickReplica() = %v, want nil", got)
+		}
+	})
+}
+
+func TestClusterReplicaRecordResult(t *testing.T) {
+	const maxFailures = int32(3)
+	const cooldown = time.Minute
+
+	t.Run("success resets the failure count", func(t *testing.T) {
+		r := &clusterReplica{consecutiveFail: maxFailures - 1}
+		r.recordResult(nil, maxFailures, cooldown)
+		if r.consecutiveFail != 0 {
+			t.Errorf("consecutiveFail = %d, want 0", r.consecutiveFail)
+		}
+		if !r.healthy(time.Now()) {
+			t.Error("replica should be healthy after a successful result")
+		}
+	})
+
+	t.Run("failures below the threshold do not demote", func(t *testing.T) {
+		r := &clusterReplica{}
+		for i := int32(0); i < maxFailures-1; i++ {
+			r.recordResult(context.DeadlineExceeded, maxFailures, cooldown)
+		}
+		if !r.healthy(time.Now()) {
+			t.Error("replica should still be healthy below the failure threshold")
+		}
+	})
+
+	t.Run("reaching the threshold demotes until the cooldown elapses", func(t *testing.T) {
+		r := &clusterReplica{}
+		for i := int32(0); i < maxFailures; i++ {
+			r.recordResult(context.DeadlineExceeded, maxFailures, cooldown)
+		}
+		if r.healthy(time.Now()) {
+			t.Error("replica should be demoted once consecutive failures reach maxFailures")
+		}
+		if !r.healthy(time.Now().Add(2 * cooldown)) {
+			t.Error("replica should be healthy again once the cooldown has elapsed")
+		}
+	})
+
+	t.Run("a promoted replica is permanently unhealthy regardless of cooldown", func(t *testing.T) {
+		r := &clusterReplica{}
+		r.markPromoted()
+		if r.healthy(time.Now().Add(24 * time.Hour)) {
+			t.Error("a promoted replica must never be reported healthy again")
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package pgxpool
+
+import (
+	"context"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/puddle"
+)
+
+// Conn is an acquired *pgx.Conn from a Pool.
+type Conn struct {
+	res *puddle.Resource
+	p   *Pool
+}
+
+// Conn returns the underlying *pgx.Conn that c wraps.
+func (c *Conn) Conn() *pgx.Conn {
+	return c.connResource().conn
+}
+
+func (c *Conn) connResource() *connResource {
+	return c.res.Value().(*connResource)
+}
+
+// connIsDirty reports whether a connection in the given state must be destroyed rather than returned to the pool:
+// closed connections obviously can't be reused, and a connection outside the idle transaction status ('I') means
+// the caller left a transaction open, so its session state can't be trusted for the next acquirer.
+func connIsDirty(closed bool, txStatus byte) bool {
+	return closed || txStatus != 'I'
+}
+
+// Release returns c's underlying connection to the pool. c must not be used again after calling Release.
+//
+// If AfterRelease returns false, or the connection is dirty (closed or mid-transaction), the connection is
+// destroyed instead of being pooled. Otherwise, if ResetConn is set, it is run with a context bounded by
+// ResetConnTimeout to scrub any leftover session state; if it errors (including by timing out), the connection is
+// destroyed rather than risk leaking that state to the next acquirer. Once the connection is actually returned to
+// the pool, Config.MetricsCollector (if any) is notified via OnRelease.
+func (c *Conn) Release() {
+	if c.res == nil {
+		return
+	}
+
+	conn := c.Conn()
+	res := c.res
+	c.res = nil
+
+	if connIsDirty(conn.IsClosed(), conn.PgConn().TxStatus()) {
+		res.Destroy()
+		return
+	}
+
+	if c.p.afterRelease != nil && !c.p.afterRelease(conn) {
+		res.Destroy()
+		return
+	}
+
+	if c.p.resetConn != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), c.p.resetConnTimeout)
+		err := c.p.resetConn(ctx, conn)
+		cancel()
+		if err != nil {
+			res.Destroy()
+			return
+		}
+	}
+
+	res.Release()
+
+	if c.p.metricsCollector != nil {
+		c.p.metricsCollector.OnRelease()
+	}
+}
+
+func (c *Conn) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	return c.Conn().Exec(ctx, sql, arguments...)
+}
+
+func (c *Conn) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return c.Conn().Query(ctx, sql, args...)
+}
+
+func (c *Conn) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return c.Conn().QueryRow(ctx, sql, args...)
+}
+
+func (c *Conn) QueryFunc(ctx context.Context, sql string, args []interface{}, scans []interface{}, f func(pgx.QueryFuncRow) error) (pgconn.CommandTag, error) {
+	return c.Conn().QueryFunc(ctx, sql, args, scans, f)
+}
+
+func (c *Conn) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return c.Conn().SendBatch(ctx, b)
+}
+
+func (c *Conn) Begin(ctx context.Context) (pgx.Tx, error) {
+	return c.Conn().Begin(ctx)
+}
+
+func (c *Conn) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	return c.Conn().BeginTx(ctx, txOptions)
+}
+
+func (c *Conn) getPoolRow(r pgx.Row) *poolRow {
+	return c.connResource().getPoolRow(c, r)
+}
+
+func (c *Conn) getPoolRows(r pgx.Rows) *poolRows {
+	return c.connResource().getPoolRows(c, r)
+}
@@ -0,0 +1,26 @@
+package pgxpool
+
+import "testing"
+
+func TestConnIsDirty(t *testing.T) {
+	tests := []struct {
+		name     string
+		closed   bool
+		txStatus byte
+		want     bool
+	}{
+		{"idle open connection is clean", false, 'I', false},
+		{"closed connection is dirty", true, 'I', true},
+		{"mid-transaction connection is dirty", false, 'T', true},
+		{"failed-transaction connection is dirty", false, 'E', true},
+		{"closed and mid-transaction is dirty", true, 'T', true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := connIsDirty(tt.closed, tt.txStatus); got != tt.want {
+				t.Errorf("connIsDirty(%v, %q) = %v, want %v", tt.closed, tt.txStatus, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package pgxpool
+
+import "time"
+
+// MetricsCollector is an optional hook for observing pool and query telemetry as it happens. Assign an
+// implementation to Config.MetricsCollector before calling ConnectConfig to receive callbacks. Implementations must
+// be safe for concurrent use since methods are called from whatever goroutine triggers the corresponding event.
+type MetricsCollector interface {
+	// OnAcquire is called after an Acquire attempt completes, whether or not it succeeded. duration is the time
+	// spent waiting for a connection, including any BeforeAcquire retries.
+	OnAcquire(duration time.Duration, err error)
+
+	// OnRelease is called after a connection has been returned to the pool.
+	OnRelease()
+
+	// OnConnect is called after a new physical connection attempt completes, whether or not it succeeded. It runs
+	// before the connection (if any) is added to the pool.
+	OnConnect(duration time.Duration, err error)
+
+	// OnQuery is called once per Pool.Exec, Query, QueryRow, or SendBatch call, including one whose Acquire failed.
+	// method is one of "Exec", "Query", "QueryRow", or "SendBatch". duration covers acquiring the connection and
+	// issuing the statement; for Query, QueryRow, and SendBatch it does not include reading the results. err is the
+	// Acquire error, or for Exec and Query the statement's error; for QueryRow and SendBatch an error that only
+	// surfaces when the results are read is not reported.
+	OnQuery(method string, duration time.Duration, err error)
+
+	// OnStat is called once per HealthCheckPeriod with the pool's current Stat snapshot.
+	OnStat(stat *Stat)
+}
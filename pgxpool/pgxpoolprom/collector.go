@@ -0,0 +1,134 @@
+// Package pgxpoolprom provides a ready-made pgxpool.MetricsCollector that exports pool health and query latency as
+// Prometheus metrics.
+package pgxpoolprom
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector implements pgxpool.MetricsCollector by registering gauges that mirror TotalConns, AcquiredConns,
+// IdleConns, ConstructingConns, CanceledAcquireCount, and EmptyAcquireCount from pgxpool.Stat, and histograms of
+// acquire, connect, and query latency. Construct one with NewCollector and assign it to
+// Config.MetricsCollector before calling pgxpool.ConnectConfig.
+type Collector struct {
+	totalConns           prometheus.Gauge
+	acquiredConns        prometheus.Gauge
+	idleConns            prometheus.Gauge
+	constructingConns    prometheus.Gauge
+	canceledAcquireCount prometheus.Gauge
+	emptyAcquireCount    prometheus.Gauge
+
+	acquireDuration prometheus.Histogram
+	connectDuration prometheus.Histogram
+	queryDuration   *prometheus.HistogramVec
+}
+
+// NewCollector creates a Collector and registers its metrics with reg. namespace and subsystem are used as the
+// usual Prometheus metric name prefix and may be left empty.
+func NewCollector(reg prometheus.Registerer, namespace, subsystem string) *Collector {
+	c := &Collector{
+		totalConns: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "total_conns",
+			Help:      "Total number of connections currently in the pool.",
+		}),
+		acquiredConns: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "acquired_conns",
+			Help:      "Number of connections currently acquired by callers.",
+		}),
+		idleConns: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "idle_conns",
+			Help:      "Number of connections currently idle in the pool.",
+		}),
+		constructingConns: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "constructing_conns",
+			Help:      "Number of connections currently being established.",
+		}),
+		canceledAcquireCount: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "canceled_acquire_count",
+			Help:      "Cumulative count of Acquire calls canceled by a context.",
+		}),
+		emptyAcquireCount: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "empty_acquire_count",
+			Help:      "Cumulative count of Acquire calls that had to wait for a resource to be released or constructed.",
+		}),
+		acquireDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "acquire_duration_seconds",
+			Help:      "Time spent acquiring a connection from the pool.",
+		}),
+		connectDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "connect_duration_seconds",
+			Help:      "Time spent establishing a new physical connection.",
+		}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "query_duration_seconds",
+			Help:      "Time spent acquiring a connection and running a statement, labeled by method.",
+		}, []string{"method"}),
+	}
+
+	reg.MustRegister(
+		c.totalConns,
+		c.acquiredConns,
+		c.idleConns,
+		c.constructingConns,
+		c.canceledAcquireCount,
+		c.emptyAcquireCount,
+		c.acquireDuration,
+		c.connectDuration,
+		c.queryDuration,
+	)
+
+	return c
+}
+
+// OnAcquire implements pgxpool.MetricsCollector.
+func (c *Collector) OnAcquire(duration time.Duration, err error) {
+	if err == nil {
+		c.acquireDuration.Observe(duration.Seconds())
+	}
+}
+
+// OnRelease implements pgxpool.MetricsCollector. Pool-wide connection counts are refreshed by OnStat instead.
+func (c *Collector) OnRelease() {}
+
+// OnConnect implements pgxpool.MetricsCollector.
+func (c *Collector) OnConnect(duration time.Duration, err error) {
+	if err == nil {
+		c.connectDuration.Observe(duration.Seconds())
+	}
+}
+
+// OnQuery implements pgxpool.MetricsCollector.
+func (c *Collector) OnQuery(method string, duration time.Duration, err error) {
+	c.queryDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+// OnStat implements pgxpool.MetricsCollector.
+func (c *Collector) OnStat(stat *pgxpool.Stat) {
+	c.totalConns.Set(float64(stat.TotalConns()))
+	c.acquiredConns.Set(float64(stat.AcquiredConns()))
+	c.idleConns.Set(float64(stat.IdleConns()))
+	c.constructingConns.Set(float64(stat.ConstructingConns()))
+	c.canceledAcquireCount.Set(float64(stat.CanceledAcquireCount()))
+	c.emptyAcquireCount.Set(float64(stat.EmptyAcquireCount()))
+}
@@ -0,0 +1,49 @@
+package pgxpoolprom
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollectorObservesAcquireAndConnectDuration(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c := NewCollector(reg, "test", "pool")
+
+	c.OnAcquire(10*time.Millisecond, nil)
+	c.OnAcquire(5*time.Millisecond, context.Canceled)
+	c.OnConnect(20*time.Millisecond, nil)
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	sampleCounts := map[string]uint64{}
+	for _, mf := range mfs {
+		if m := mf.GetMetric(); len(m) == 1 && m[0].GetHistogram() != nil {
+			sampleCounts[mf.GetName()] = m[0].GetHistogram().GetSampleCount()
+		}
+	}
+
+	if got := sampleCounts["test_pool_acquire_duration_seconds"]; got != 1 {
+		t.Errorf("acquireDuration observed %d times, want 1 (a failed acquire should not be observed)", got)
+	}
+	if got := sampleCounts["test_pool_connect_duration_seconds"]; got != 1 {
+		t.Errorf("connectDuration observed %d times, want 1", got)
+	}
+}
+
+func TestCollectorOnQueryLabelsByMethod(t *testing.T) {
+	c := NewCollector(prometheus.NewRegistry(), "test", "pool")
+
+	c.OnQuery("Query", time.Millisecond, nil)
+	c.OnQuery("Exec", time.Millisecond, nil)
+	c.OnQuery("Query", time.Millisecond, nil)
+
+	if got := testutil.CollectAndCount(c.queryDuration); got != 2 {
+		t.Errorf("queryDuration has %d label combinations, want 2 (Query, Exec)", got)
+	}
+}
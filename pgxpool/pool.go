@@ -4,10 +4,12 @@ import (
 	"context"
 	"runtime"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stmtcache"
 	"github.com/jackc/puddle"
 	errors "golang.org/x/xerrors"
 )
@@ -17,6 +19,16 @@ var defaultMinConns = int32(0)
 var defaultMaxConnLifetime = time.Hour
 var defaultMaxConnIdleTime = time.Minute * 30
 var defaultHealthCheckPeriod = time.Minute
+var defaultResetConnTimeout = 5 * time.Second
+
+// ErrAcquireTimeout is returned by Acquire when Config.AcquireTimeout elapses before a connection becomes
+// available, and the pool still has room to grow. See ErrPoolExhausted for the case where the pool is already at
+// MaxConns.
+var ErrAcquireTimeout = errors.New("pgxpool: acquire timeout")
+
+// ErrPoolExhausted is returned by Acquire instead of ErrAcquireTimeout when Config.AcquireTimeout elapses while
+// the pool is already at MaxConns, i.e. no amount of additional waiting would have helped.
+var ErrPoolExhausted = errors.New("pgxpool: pool exhausted")
 
 type connResource struct {
 	conn      *pgx.Conn
@@ -74,11 +86,16 @@ type Pool struct {
 	afterConnect      func(context.Context, *pgx.Conn) error
 	beforeAcquire     func(context.Context, *pgx.Conn) bool
 	afterRelease      func(*pgx.Conn) bool
+	resetConn         func(context.Context, *pgx.Conn) error
+	resetConnTimeout  time.Duration
 	minConns          int32
 	maxConnLifetime   time.Duration
 	maxConnIdleTime   time.Duration
 	healthCheckPeriod time.Duration
 	closeChan         chan struct{}
+	metricsCollector  MetricsCollector
+	acquireTimeout    time.Duration
+	acquireTimeouts   uint64 // atomic
 }
 
 // Config is the configuration struct for creating a pool. It must be created by ParseConfig and then it can be
@@ -102,6 +119,18 @@ type Config struct {
 	// return the connection to the pool or false to destroy the connection.
 	AfterRelease func(*pgx.Conn) bool
 
+	// ResetConn is called by Conn.Release after AfterRelease returns true but before the connection is returned to
+	// the pool. It should scrub any per-session state left behind by the caller (temp tables, prepared statements
+	// created with PREPARE, SET LOCAL leftovers from an aborted transaction, advisory locks, ...) so it never
+	// leaks to the next acquirer — for example by issuing "DISCARD ALL". It is given a context bounded by
+	// ResetConnTimeout; if it returns an error (including from that deadline), the connection is destroyed rather
+	// than pooled. The default is nil, which pools connections as-is.
+	ResetConn func(context.Context, *pgx.Conn) error
+
+	// ResetConnTimeout bounds how long ResetConn is allowed to run. The default is 5 seconds. Ignored if
+	// ResetConn is nil.
+	ResetConnTimeout time.Duration
+
 	// MaxConnLifetime is the duration since creation after which a connection will be automatically closed.
 	MaxConnLifetime time.Duration
 
@@ -118,11 +147,39 @@ type Config struct {
 	// HealthCheckPeriod is the duration between checks of the health of idle connections.
 	HealthCheckPeriod time.Duration
 
+	// AcquireTimeout, if non-zero, bounds how long Acquire will wait for a connection regardless of ctx's own
+	// deadline. Parsed from the pool_acquire_timeout DSN parameter. When it elapses, Acquire returns
+	// ErrPoolExhausted if the pool is at MaxConns, or ErrAcquireTimeout otherwise.
+	AcquireTimeout time.Duration
+
 	// If set to true, pool doesn't do any I/O operation on initialization.
 	// And connects to the server only when the pool starts to be used.
 	// The default is false.
 	LazyConnect bool
 
+	// StatementCacheCapacity sets the capacity of the stmtcache.Cache (in stmtcache.ModePrepare) that
+	// ConnConfig.BuildStatementCache builds for each connection in the pool. Parsed from the
+	// pool_statement_cache_capacity DSN parameter. When it is zero and no description cache is in effect (see
+	// DescriptionCacheCapacity), pgx's own default BuildStatementCache is left in place.
+	StatementCacheCapacity int
+
+	// DescriptionCacheCapacity sets the capacity of the stmtcache.Cache (in stmtcache.ModeDescribe) that
+	// ConnConfig.BuildStatementCache builds for each connection in the pool. Parsed from the
+	// pool_description_cache_capacity DSN parameter. Since pgx v4 supports only one active statement cache per
+	// connection, it only takes effect when QueryExecMode is "cache_describe", or when QueryExecMode is empty and
+	// StatementCacheCapacity is not set.
+	DescriptionCacheCapacity int
+
+	// QueryExecMode chooses how ConnConfig.BuildStatementCache and ConnConfig.PreferSimpleProtocol are set up for
+	// every connection in the pool. Parsed from the pool_query_exec_mode DSN parameter; one of "cache_statement",
+	// "cache_describe", "describe_exec", "exec", or "simple_protocol". Empty leaves pgx's own defaults in place.
+	QueryExecMode string
+
+	// MetricsCollector, if set, is notified of pool and query events (acquires, releases, connects, queries, and
+	// periodic Stat snapshots) so they can be exported to an observability backend. See pgxpool/pgxpoolprom for a
+	// ready-made Prometheus implementation.
+	MetricsCollector MetricsCollector
+
 	createdByParseConfig bool // Used to enforce created by ParseConfig rule.
 }
 
@@ -158,32 +215,54 @@ func ConnectConfig(ctx context.Context, config *Config) (*Pool, error) {
 		panic("config must be created by ParseConfig")
 	}
 
+	// Guarantee that a SensitiveData value passed as a query argument never reaches whatever backend ConnConfig.Logger
+	// forwards to, without requiring every caller to remember to opt in themselves.
+	if config.ConnConfig.Logger != nil {
+		config.ConnConfig.Logger = pgx.NewRedactingLogger(config.ConnConfig.Logger)
+	}
+
 	p := &Pool{
 		config:            config,
 		beforeConnect:     config.BeforeConnect,
 		afterConnect:      config.AfterConnect,
 		beforeAcquire:     config.BeforeAcquire,
 		afterRelease:      config.AfterRelease,
+		resetConn:         config.ResetConn,
+		resetConnTimeout:  config.ResetConnTimeout,
 		minConns:          config.MinConns,
 		maxConnLifetime:   config.MaxConnLifetime,
 		maxConnIdleTime:   config.MaxConnIdleTime,
 		healthCheckPeriod: config.HealthCheckPeriod,
 		closeChan:         make(chan struct{}),
+		metricsCollector:  config.MetricsCollector,
+		acquireTimeout:    config.AcquireTimeout,
+	}
+
+	if p.resetConn != nil && p.resetConnTimeout == 0 {
+		p.resetConnTimeout = defaultResetConnTimeout
 	}
 
 	p.p = puddle.NewPool(
 		func(ctx context.Context) (interface{}, error) {
+			start := time.Now()
+
 			connConfig := p.config.ConnConfig
 
 			if p.beforeConnect != nil {
 				connConfig = p.config.ConnConfig.Copy()
 				if err := p.beforeConnect(ctx, connConfig); err != nil {
+					if p.metricsCollector != nil {
+						p.metricsCollector.OnConnect(time.Since(start), err)
+					}
 					return nil, err
 				}
 			}
 
 			conn, err := pgx.ConnectConfig(ctx, connConfig)
 			if err != nil {
+				if p.metricsCollector != nil {
+					p.metricsCollector.OnConnect(time.Since(start), err)
+				}
 				return nil, err
 			}
 
@@ -191,10 +270,17 @@ func ConnectConfig(ctx context.Context, config *Config) (*Pool, error) {
 				err = p.afterConnect(ctx, conn)
 				if err != nil {
 					conn.Close(ctx)
+					if p.metricsCollector != nil {
+						p.metricsCollector.OnConnect(time.Since(start), err)
+					}
 					return nil, err
 				}
 			}
 
+			if p.metricsCollector != nil {
+				p.metricsCollector.OnConnect(time.Since(start), nil)
+			}
+
 			cr := &connResource{
 				conn:      conn,
 				conns:     make([]Conn, 64),
@@ -240,6 +326,10 @@ func ConnectConfig(ctx context.Context, config *Config) (*Pool, error) {
 // pool_max_conn_lifetime: duration string
 // pool_max_conn_idle_time: duration string
 // pool_health_check_period: duration string
+// pool_acquire_timeout: duration string
+// pool_statement_cache_capacity: integer 0 or greater
+// pool_description_cache_capacity: integer 0 or greater
+// pool_query_exec_mode: cache_statement, cache_describe, describe_exec, exec, or simple_protocol
 //
 // See Config for definitions of these arguments.
 //
@@ -320,9 +410,96 @@ func ParseConfig(connString string) (*Config, error) {
 		config.HealthCheckPeriod = defaultHealthCheckPeriod
 	}
 
+	if s, ok := config.ConnConfig.Config.RuntimeParams["pool_acquire_timeout"]; ok {
+		delete(connConfig.Config.RuntimeParams, "pool_acquire_timeout")
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, errors.Errorf("invalid pool_acquire_timeout: %w", err)
+		}
+		config.AcquireTimeout = d
+	}
+
+	if s, ok := config.ConnConfig.Config.RuntimeParams["pool_statement_cache_capacity"]; ok {
+		delete(connConfig.Config.RuntimeParams, "pool_statement_cache_capacity")
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return nil, errors.Errorf("cannot parse pool_statement_cache_capacity: %w", err)
+		}
+		config.StatementCacheCapacity = int(n)
+	}
+
+	if s, ok := config.ConnConfig.Config.RuntimeParams["pool_description_cache_capacity"]; ok {
+		delete(connConfig.Config.RuntimeParams, "pool_description_cache_capacity")
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return nil, errors.Errorf("cannot parse pool_description_cache_capacity: %w", err)
+		}
+		config.DescriptionCacheCapacity = int(n)
+	}
+
+	if s, ok := config.ConnConfig.Config.RuntimeParams["pool_query_exec_mode"]; ok {
+		delete(connConfig.Config.RuntimeParams, "pool_query_exec_mode")
+		if err := validateQueryExecMode(s); err != nil {
+			return nil, errors.Errorf("cannot parse pool_query_exec_mode: %w", err)
+		}
+		config.QueryExecMode = s
+	}
+
+	if err := applyStatementCaching(config); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
 
+// validateQueryExecMode reports an error if s is not one of the recognized pool_query_exec_mode values.
+func validateQueryExecMode(s string) error {
+	switch s {
+	case "cache_statement", "cache_describe", "describe_exec", "exec", "simple_protocol":
+		return nil
+	default:
+		return errors.Errorf("unknown query exec mode: %s", s)
+	}
+}
+
+// applyStatementCaching translates StatementCacheCapacity, DescriptionCacheCapacity, and QueryExecMode into pgx
+// v4's actual statement-caching knobs: ConnConfig.BuildStatementCache (which builds a stmtcache.Cache in either
+// ModePrepare or ModeDescribe) and ConnConfig.PreferSimpleProtocol. pgx v4 only has one active cache per
+// connection, so an explicit "simple_protocol" or "exec"/"describe_exec" QueryExecMode takes precedence over the
+// capacity params. DescriptionCacheCapacity takes effect when QueryExecMode is "cache_describe", or when
+// QueryExecMode is empty and StatementCacheCapacity was not also set. It is an error to explicitly request "cache_describe" without also giving it a capacity, since silently leaving
+// pgx's ModePrepare default cache in place would ignore the caller's choice.
+func applyStatementCaching(config *Config) error {
+	switch config.QueryExecMode {
+	case "simple_protocol":
+		config.ConnConfig.PreferSimpleProtocol = true
+		config.ConnConfig.BuildStatementCache = nil
+		return nil
+	case "exec", "describe_exec":
+		config.ConnConfig.BuildStatementCache = nil
+		return nil
+	}
+
+	mode := stmtcache.ModePrepare
+	capacity := config.StatementCacheCapacity
+	if config.QueryExecMode == "cache_describe" || (config.QueryExecMode == "" && capacity == 0 && config.DescriptionCacheCapacity > 0) {
+		mode = stmtcache.ModeDescribe
+		capacity = config.DescriptionCacheCapacity
+	}
+
+	if capacity == 0 {
+		if config.QueryExecMode == "cache_describe" {
+			return errors.New("pool_query_exec_mode=cache_describe requires pool_description_cache_capacity to be set")
+		}
+		return nil // leave pgx's own default BuildStatementCache in place
+	}
+
+	config.ConnConfig.BuildStatementCache = func(conn *pgconn.PgConn) stmtcache.Cache {
+		return stmtcache.New(conn, mode, capacity)
+	}
+	return nil
+}
+
 // Close closes all connections in the pool and rejects future Acquire calls. Blocks until all connections are returned
 // to pool and closed.
 func (p *Pool) Close() {
@@ -341,6 +518,9 @@ func (p *Pool) backgroundHealthCheck() {
 		case <-ticker.C:
 			p.checkIdleConnsHealth()
 			p.checkMinConns()
+			if p.metricsCollector != nil {
+				p.metricsCollector.OnStat(p.Stat())
+			}
 		}
 	}
 }
@@ -370,15 +550,45 @@ func (p *Pool) checkMinConns() {
 	}
 }
 
+// classifyAcquireTimeout decides which error Acquire should report once Config.AcquireTimeout has elapsed:
+// ErrPoolExhausted if the pool was already at MaxConns (no amount of additional waiting would have helped), or
+// ErrAcquireTimeout otherwise.
+func classifyAcquireTimeout(totalConns, maxConns int32) error {
+	if totalConns >= maxConns {
+		return ErrPoolExhausted
+	}
+	return ErrAcquireTimeout
+}
+
 func (p *Pool) Acquire(ctx context.Context) (*Conn, error) {
+	start := time.Now()
+
+	acquireCtx := ctx
+	if p.acquireTimeout > 0 {
+		var cancel context.CancelFunc
+		acquireCtx, cancel = context.WithTimeout(ctx, p.acquireTimeout)
+		defer cancel()
+	}
+
 	for {
-		res, err := p.p.Acquire(ctx)
+		res, err := p.p.Acquire(acquireCtx)
 		if err != nil {
+			// Only treat this as our own fail-fast timeout if the caller's ctx is not itself what expired.
+			if p.acquireTimeout > 0 && errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				atomic.AddUint64(&p.acquireTimeouts, 1)
+				err = classifyAcquireTimeout(p.Stat().TotalConns(), p.config.MaxConns)
+			}
+			if p.metricsCollector != nil {
+				p.metricsCollector.OnAcquire(time.Since(start), err)
+			}
 			return nil, err
 		}
 
 		cr := res.Value().(*connResource)
 		if p.beforeAcquire == nil || p.beforeAcquire(ctx, cr.conn) {
+			if p.metricsCollector != nil {
+				p.metricsCollector.OnAcquire(time.Since(start), nil)
+			}
 			return cr.getConn(p, res), nil
 		}
 
@@ -407,44 +617,67 @@ func (p *Pool) AcquireAllIdle(ctx context.Context) []*Conn {
 func (p *Pool) Config() *Config { return p.config.Copy() }
 
 func (p *Pool) Stat() *Stat {
-	return &Stat{s: p.p.Stat()}
+	return &Stat{s: p.p.Stat(), acquireTimeouts: atomic.LoadUint64(&p.acquireTimeouts)}
 }
 
 func (p *Pool) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	start := time.Now()
+
 	c, err := p.Acquire(ctx)
 	if err != nil {
+		p.onQuery("Exec", start, err)
 		return nil, err
 	}
 	defer c.Release()
 
-	return c.Exec(ctx, sql, arguments...)
+	ct, err := c.Exec(ctx, sql, arguments...)
+	p.onQuery("Exec", start, err)
+	return ct, err
 }
 
 func (p *Pool) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	start := time.Now()
+
 	c, err := p.Acquire(ctx)
 	if err != nil {
+		p.onQuery("Query", start, err)
 		return errRows{err: err}, err
 	}
 
 	rows, err := c.Query(ctx, sql, args...)
 	if err != nil {
 		c.Release()
+		p.onQuery("Query", start, err)
 		return errRows{err: err}, err
 	}
 
+	p.onQuery("Query", start, nil)
 	return c.getPoolRows(rows), nil
 }
 
 func (p *Pool) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	start := time.Now()
+
 	c, err := p.Acquire(ctx)
 	if err != nil {
+		p.onQuery("QueryRow", start, err)
 		return errRow{err: err}
 	}
 
 	row := c.QueryRow(ctx, sql, args...)
+	p.onQuery("QueryRow", start, nil)
 	return c.getPoolRow(row)
 }
 
+// onQuery reports a completed Exec/Query/QueryRow/SendBatch to the configured MetricsCollector, if any. Note that
+// for Query, QueryRow, and SendBatch, "completed" covers acquiring the connection and issuing the statement, not
+// reading the returned row(s)/results.
+func (p *Pool) onQuery(method string, start time.Time, err error) {
+	if p.metricsCollector != nil {
+		p.metricsCollector.OnQuery(method, time.Since(start), err)
+	}
+}
+
 func (p *Pool) QueryFunc(ctx context.Context, sql string, args []interface{}, scans []interface{}, f func(pgx.QueryFuncRow) error) (pgconn.CommandTag, error) {
 	c, err := p.Acquire(ctx)
 	if err != nil {
@@ -456,12 +689,16 @@ func (p *Pool) QueryFunc(ctx context.Context, sql string, args []interface{}, sc
 }
 
 func (p *Pool) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	start := time.Now()
+
 	c, err := p.Acquire(ctx)
 	if err != nil {
+		p.onQuery("SendBatch", start, err)
 		return errBatchResults{err: err}
 	}
 
 	br := c.SendBatch(ctx, b)
+	p.onQuery("SendBatch", start, nil)
 	return &poolBatchResults{br: br, c: c}
 }
 
@@ -0,0 +1,95 @@
+package pgxpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+func TestClassifyAcquireTimeout(t *testing.T) {
+	tests := []struct {
+		name                 string
+		totalConns, maxConns int32
+		want                 error
+	}{
+		{"below max", 3, 10, ErrAcquireTimeout},
+		{"at max", 10, 10, ErrPoolExhausted},
+		{"above max", 11, 10, ErrPoolExhausted},
+		{"zero max", 0, 0, ErrPoolExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyAcquireTimeout(tt.totalConns, tt.maxConns)
+			if got != tt.want {
+				t.Errorf("classifyAcquireTimeout(%d, %d) = %v, want %v", tt.totalConns, tt.maxConns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyStatementCaching(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"default leaves pgx's own cache in place", Config{}, false},
+		{"explicit cache_statement with capacity", Config{QueryExecMode: "cache_statement", StatementCacheCapacity: 16}, false},
+		{"explicit cache_describe with capacity", Config{QueryExecMode: "cache_describe", DescriptionCacheCapacity: 16}, false},
+		{"explicit cache_describe without capacity is an error", Config{QueryExecMode: "cache_describe"}, true},
+		{"simple_protocol ignores capacities", Config{QueryExecMode: "simple_protocol", StatementCacheCapacity: 16, DescriptionCacheCapacity: 16}, false},
+		{"exec ignores capacities", Config{QueryExecMode: "exec", StatementCacheCapacity: 16, DescriptionCacheCapacity: 16}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.ConnConfig = &pgx.ConnConfig{}
+
+			err := applyStatementCaching(&config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("applyStatementCaching(%+v) error = %v, wantErr %v", tt.config, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type fakeMetricsCollector struct {
+	queries []string
+	errs    []error
+}
+
+func (f *fakeMetricsCollector) OnAcquire(time.Duration, error) {}
+func (f *fakeMetricsCollector) OnRelease()                     {}
+func (f *fakeMetricsCollector) OnConnect(time.Duration, error) {}
+func (f *fakeMetricsCollector) OnStat(*Stat)                   {}
+func (f *fakeMetricsCollector) OnQuery(method string, _ time.Duration, err error) {
+	f.queries = append(f.queries, method)
+	f.errs = append(f.errs, err)
+}
+
+func TestPoolOnQuery(t *testing.T) {
+	collector := &fakeMetricsCollector{}
+	p := &Pool{metricsCollector: collector}
+
+	p.onQuery("Query", time.Now(), nil)
+	p.onQuery("Exec", time.Now(), context.DeadlineExceeded)
+
+	if len(collector.queries) != 2 {
+		t.Fatalf("OnQuery called %d times, want 2", len(collector.queries))
+	}
+	if collector.queries[0] != "Query" || collector.errs[0] != nil {
+		t.Errorf("call 0 = (%q, %v), want (%q, nil)", collector.queries[0], collector.errs[0], "Query")
+	}
+	if collector.queries[1] != "Exec" || collector.errs[1] != context.DeadlineExceeded {
+		t.Errorf("call 1 = (%q, %v), want (%q, %v)", collector.queries[1], collector.errs[1], "Exec", context.DeadlineExceeded)
+	}
+}
+
+func TestPoolOnQueryNoCollector(t *testing.T) {
+	p := &Pool{}
+	p.onQuery("Query", time.Now(), nil) // must not panic with no MetricsCollector configured
+}
@@ -0,0 +1,46 @@
+package pgxpool
+
+import (
+	"time"
+
+	"github.com/jackc/puddle"
+)
+
+// Stat is a snapshot of pool statistics.
+type Stat struct {
+	s               *puddle.Stat
+	acquireTimeouts uint64
+}
+
+// AcquireCount returns the cumulative count of successful acquires from the pool.
+func (s *Stat) AcquireCount() int64 { return s.s.AcquireCount() }
+
+// AcquireDuration returns the total duration of all successful acquires from the pool.
+func (s *Stat) AcquireDuration() time.Duration { return s.s.AcquireDuration() }
+
+// AcquiredConns returns the number of currently acquired connections in the pool.
+func (s *Stat) AcquiredConns() int32 { return s.s.AcquiredResources() }
+
+// AcquireTimeouts returns the cumulative count of acquires that failed because they exceeded
+// Config.AcquireTimeout.
+func (s *Stat) AcquireTimeouts() uint64 { return s.acquireTimeouts }
+
+// CanceledAcquireCount returns the cumulative count of acquires canceled by a context.
+func (s *Stat) CanceledAcquireCount() int64 { return s.s.CanceledAcquireCount() }
+
+// ConstructingConns returns the number of conns with a construction in progress.
+func (s *Stat) ConstructingConns() int32 { return s.s.ConstructingResources() }
+
+// EmptyAcquireCount returns the cumulative count of successful acquires that waited for a resource to be
+// released or constructed because the pool was empty.
+func (s *Stat) EmptyAcquireCount() int64 { return s.s.EmptyAcquireCount() }
+
+// IdleConns returns the number of currently idle connections in the pool.
+func (s *Stat) IdleConns() int32 { return s.s.IdleResources() }
+
+// MaxConns returns the maximum size of the pool.
+func (s *Stat) MaxConns() int32 { return s.s.MaxResources() }
+
+// TotalConns returns the total number of resources currently in the pool, including those that are acquired,
+// idle, or being constructed.
+func (s *Stat) TotalConns() int32 { return s.s.TotalResources() }
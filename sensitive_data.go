@@ -0,0 +1,140 @@
+package pgx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+const redactedPlaceholder = "<redacted>"
+
+// SensitiveData wraps a value that must never reach logs or error messages — only the wire protocol.
+// convertDriverValuers unwraps it when encoding query arguments. Its String/Format implementation guarantees that
+// any fmt-based formatting of the value itself — including one embedded in an error message — prints the
+// placeholder "<redacted>" instead of Value; this holds unconditionally, with no opt-in required. For pgx's own
+// query-start/query-end log events, wrap your Logger with NewRedactingLogger (pgxpool does this automatically for
+// any ConnConfig.Logger it is given) so the "args" data passed to Log is redacted before your Logger sees it.
+// pgconn.PgError itself only carries fields reported by the server, so it cannot surface a client-side
+// SensitiveData value.
+type SensitiveData struct {
+	Value interface{}
+}
+
+// String implements fmt.Stringer so an accidental %v, %s, or Println on a SensitiveData never prints its Value.
+func (SensitiveData) String() string { return redactedPlaceholder }
+
+// Format implements fmt.Formatter for the same reason String does, covering verbs like %#v and %+v that would
+// otherwise reflect into the wrapped Value instead of calling String.
+func (SensitiveData) Format(f fmt.State, verb rune) {
+	fmt.Fprint(f, redactedPlaceholder)
+}
+
+var sensitiveDataType = reflect.TypeOf(SensitiveData{})
+
+// Redact walks v — following pointers and interfaces, and recursing into structs, maps, slices, and arrays — and
+// returns a copy with every SensitiveData value replaced by the placeholder "<redacted>". Structs and maps are
+// returned as map[string]interface{} rather than their original type, since the result is meant for logging, not
+// further computation. Unexported struct fields are omitted rather than risk panicking via reflection.
+func Redact(v interface{}) interface{} {
+	return redactValue(reflect.ValueOf(v))
+}
+
+func redactValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if v.Type() == sensitiveDataType {
+		return redactedPlaceholder
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem())
+
+	case reflect.Slice, reflect.Array:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = redactValue(v.Index(i))
+		}
+		return out
+
+	case reflect.Map:
+		out := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			out[fmt.Sprint(key.Interface())] = redactValue(v.MapIndex(key))
+		}
+		return out
+
+	case reflect.Struct:
+		t := v.Type()
+		out := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).PkgPath != "" { // unexported
+				continue
+			}
+			out[t.Field(i).Name] = redactValue(v.Field(i))
+		}
+		return out
+
+	default:
+		return v.Interface()
+	}
+}
+
+// redactArgs returns a copy of args with each SensitiveData element (including ones nested in structs, maps,
+// slices, or arrays) replaced by the placeholder "<redacted>". Every other element is passed through Redact, so
+// scalar values are unchanged while structs and maps come back as map[string]interface{} and slices and arrays as
+// []interface{}.
+func redactArgs(args []interface{}) []interface{} {
+	redacted := make([]interface{}, len(args))
+	for i, arg := range args {
+		if _, ok := arg.(SensitiveData); ok {
+			redacted[i] = redactedPlaceholder
+			continue
+		}
+		redacted[i] = Redact(arg)
+	}
+	return redacted
+}
+
+// redactingLogger wraps a Logger so that any SensitiveData values in a log call's data — most importantly the
+// "args" pgx attaches to its query-start log event — are replaced with a placeholder before the wrapped Logger
+// ever sees them.
+type redactingLogger struct {
+	logger Logger
+}
+
+// NewRedactingLogger wraps logger so Log events report redacted data instead of the raw values. Use this to
+// guarantee values wrapped in SensitiveData never reach a logging or observability backend plugged in via
+// ConnConfig.Logger. pgxpool.ConnectConfig applies this automatically to any Logger set on its ConnConfig.
+func NewRedactingLogger(logger Logger) Logger {
+	return &redactingLogger{logger: logger}
+}
+
+func (l *redactingLogger) Log(ctx context.Context, level LogLevel, msg string, data map[string]interface{}) {
+	l.logger.Log(ctx, level, msg, redactLogData(data))
+}
+
+func redactLogData(data map[string]interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+
+	out := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		if args, ok := v.([]interface{}); ok {
+			out[k] = redactArgs(args)
+			continue
+		}
+		if _, ok := v.(SensitiveData); ok {
+			out[k] = redactedPlaceholder
+			continue
+		}
+		out[k] = Redact(v)
+	}
+	return out
+}
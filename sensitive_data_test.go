@@ -0,0 +1,82 @@
+package pgx
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSensitiveDataFormatting(t *testing.T) {
+	sd := SensitiveData{Value: "super-secret"}
+
+	if got := sd.String(); got != redactedPlaceholder {
+		t.Errorf("String() = %q, want %q", got, redactedPlaceholder)
+	}
+
+	for _, verb := range []string{"%v", "%s", "%+v", "%#v"} {
+		if got := fmt.Sprintf(verb, sd); got != redactedPlaceholder {
+			t.Errorf("Sprintf(%q, sd) = %q, want %q", verb, got, redactedPlaceholder)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	type creds struct {
+		User     string
+		Password SensitiveData
+	}
+
+	tests := []struct {
+		name string
+		in   interface{}
+		want interface{}
+	}{
+		{"plain value passthrough", 42, 42},
+		{"nil", nil, nil},
+		{"top level sensitive", SensitiveData{Value: "x"}, redactedPlaceholder},
+		{
+			"nested in struct",
+			creds{User: "jack", Password: SensitiveData{Value: "hunter2"}},
+			map[string]interface{}{"User": "jack", "Password": redactedPlaceholder},
+		},
+		{
+			"nested in slice",
+			[]interface{}{"sql", SensitiveData{Value: "hunter2"}},
+			[]interface{}{"sql", redactedPlaceholder},
+		},
+		{
+			"nested in pointer",
+			&creds{User: "jack", Password: SensitiveData{Value: "hunter2"}},
+			map[string]interface{}{"User": "jack", "Password": redactedPlaceholder},
+		},
+		{
+			"nested in map",
+			map[string]interface{}{"password": SensitiveData{Value: "hunter2"}},
+			map[string]interface{}{"password": redactedPlaceholder},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Redact(tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Redact(%#v) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	args := []interface{}{"select 1", 42, SensitiveData{Value: "hunter2"}}
+
+	got := redactArgs(args)
+
+	want := []interface{}{"select 1", 42, redactedPlaceholder}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("redactArgs(%#v) = %#v, want %#v", args, got, want)
+	}
+
+	if _, ok := args[2].(SensitiveData); !ok {
+		t.Error("redactArgs mutated the original args slice in place")
+	}
+}